@@ -0,0 +1,29 @@
+package daemon
+
+import "flag"
+
+// Configuration holds the CNI daemon's command line flags.
+type Configuration struct {
+	// Mtu is used for pod interfaces when neither the subnet spec nor the
+	// MTUAnnotation overrides it.
+	Mtu int
+	// EnableOVSDBClient makes the daemon maintain a persistent OVSDB
+	// connection for port add/del instead of shelling out to ovs-vsctl on
+	// every pod event. Kept as a flag, defaulting on, so it can be turned
+	// off for debugging.
+	EnableOVSDBClient bool
+	// OvsSocket is the ovsdb-server endpoint used when EnableOVSDBClient is
+	// set, e.g. "unix:/var/run/openvswitch/db.sock". Empty uses the
+	// ovsclient package default.
+	OvsSocket string
+}
+
+// ParseFlags parses the daemon's command line flags into a Configuration.
+func ParseFlags() *Configuration {
+	config := &Configuration{}
+	flag.IntVar(&config.Mtu, "mtu", defaultMTU, "MTU for pod interfaces, overridden by the subnet spec or the "+MTUAnnotation+" annotation")
+	flag.BoolVar(&config.EnableOVSDBClient, "enable-ovsdb-client", true, "use a persistent OVSDB connection instead of shelling out to ovs-vsctl for every pod event")
+	flag.StringVar(&config.OvsSocket, "ovsdb-socket", "", "path to the local ovsdb-server socket, e.g. unix:/var/run/openvswitch/db.sock")
+	flag.Parse()
+	return config
+}
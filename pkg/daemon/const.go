@@ -0,0 +1,51 @@
+package daemon
+
+// defaultMTU is used when neither the --mtu daemon flag nor the per-pod/
+// per-subnet override is set.
+const defaultMTU = 1400
+
+// MTUAnnotation lets a pod request an MTU that differs from the subnet/
+// daemon default, e.g. when it sits behind an overlay with extra
+// encapsulation overhead.
+const MTUAnnotation = "ovn.kubernetes.io/mtu"
+
+// RoutesAnnotation carries extra static routes, in addition to the default
+// gateway, that should be installed in the pod netns.
+const RoutesAnnotation = "ovn.kubernetes.io/routes"
+
+// Route is a single static route to install in a pod's netns, sourced from
+// the subnet spec or the RoutesAnnotation.
+type Route struct {
+	Destination string `json:"dst"`
+	Gateway     string `json:"gw"`
+}
+
+// IPAddressAnnotation and MacAddressAnnotation let a workload request a
+// specific static IP/MAC, e.g. for license-bound VNFs or DHCP reservations.
+// They mirror the CNI_ARGS keys "IP" and "MAC" accepted by the CNI plugin.
+const (
+	IPAddressAnnotation  = "ovn.kubernetes.io/ip_address"
+	MacAddressAnnotation = "ovn.kubernetes.io/mac_address"
+)
+
+// SysctlsAnnotation carries a set of sysctls, keyed by their dotted
+// /proc/sys path (e.g. "net.ipv4.conf.eth0.rp_filter"), to apply inside the
+// pod netns once its interfaces are configured.
+const SysctlsAnnotation = "ovn.kubernetes.io/sysctls"
+
+// AttachNetworksAnnotation requests additional interfaces, each backed by
+// its own logical switch, beyond the pod's default eth0.
+const AttachNetworksAnnotation = "ovn.kubernetes.io/attach-networks"
+
+// Attachment describes one secondary network a pod should be wired to, on
+// top of the default eth0 interface.
+type Attachment struct {
+	IfName         string  `json:"ifName"`
+	Switch         string  `json:"switch"`
+	IP             string  `json:"ip"`
+	Mac            string  `json:"mac"`
+	MTU            int     `json:"mtu"`
+	Gateway        string  `json:"gateway"`
+	DefaultGateway bool    `json:"defaultGateway"`
+	Routes         []Route `json:"routes"`
+}
@@ -0,0 +1,29 @@
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StartDaemon is the CNI daemon's entry point, invoked once at process
+// startup: it wires the OVSDB client (or the ovs-vsctl fallback), provisions
+// the node's ovn0 internal port, and serves the CNI ADD/DEL HTTP API that
+// CmdAdd/CmdDel are reached through.
+func StartDaemon(config *Configuration, nodeName, nodeIP, nodeMAC, joinSubnetCIDR, podCIDR, listenAddr string) error {
+	handler, err := NewCniServerHandler(config)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := handler.setupNodePort(nodeName, nodeIP, nodeMAC, joinSubnetCIDR, podCIDR); err != nil {
+		return fmt.Errorf("failed to set up node port: %v", err)
+	}
+	// TODO(chunk0-7 followup): report the mac/ip setupNodePort picked to the
+	// central controller, so it can create the matching logical switch port
+	// on the node-join switch. That needs a kube client, which this package
+	// doesn't have yet.
+
+	mux := http.NewServeMux()
+	NewServer(handler).Bind(mux)
+	return http.ListenAndServe(listenAddr, mux)
+}
@@ -0,0 +1,213 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/kubeovn/kube-ovn/pkg/ovsclient"
+)
+
+// CniServerHandler serves the CNI daemon's ADD/DEL requests: it resolves
+// per-pod overrides from annotations/CNI_ARGS on top of the daemon/subnet
+// defaults, then drives the netlink/OVS mechanics in ovs.go.
+type CniServerHandler struct {
+	Config *Configuration
+
+	// ovsClient is non-nil when Config.EnableOVSDBClient is set; ovsAddPort/
+	// ovsDelPort fall back to shelling out to ovs-vsctl when it's nil.
+	ovsClient *ovsclient.Client
+}
+
+// NewCniServerHandler wires a CniServerHandler from config, connecting the
+// native OVSDB client unless config.EnableOVSDBClient is false.
+func NewCniServerHandler(config *Configuration) (*CniServerHandler, error) {
+	csc := &CniServerHandler{Config: config}
+	if config.EnableOVSDBClient {
+		c, err := ovsclient.New(config.OvsSocket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to ovsdb: %v", err)
+		}
+		csc.ovsClient = c
+	}
+	return csc, nil
+}
+
+// PodRequest bundles everything the CNI server learns about an ADD call:
+// values allocated by IPAM/the subnet controller, and the pod's own
+// annotations / CNI_ARGS, which CmdAdd resolves against each other before
+// calling down into configureNic.
+type PodRequest struct {
+	PodName      string
+	PodNamespace string
+	Netns        string
+	ContainerID  string
+
+	// IP/Mac are the IPAM-allocated address; CmdAdd overrides them with a
+	// validated static request from CniArgsIP/CniArgsMAC or the equivalent
+	// annotation, if either is set.
+	IP  string
+	Mac string
+
+	// CniArgsIP/CniArgsMAC come from CNI_ARGS ("IP"/"MAC") and take
+	// priority over IPAddressAnnotation/MacAddressAnnotation.
+	CniArgsIP  string
+	CniArgsMAC string
+
+	SubnetCIDR string
+	// SubnetMTU is the subnet's own MTU override, if any; it takes
+	// priority over the daemon's --mtu flag but not over MTUAnnotation.
+	SubnetMTU int
+
+	// GatewayIP/DefaultGateway/SubnetRoutes come from the subnet spec;
+	// RoutesAnnotation entries are appended to SubnetRoutes.
+	GatewayIP      string
+	DefaultGateway bool
+	SubnetRoutes   []Route
+
+	Annotations map[string]string
+}
+
+// CmdAdd resolves the pod's MTU from the daemon flag, the subnet spec and
+// MTUAnnotation (in increasing priority), merges the subnet's static routes
+// with RoutesAnnotation, then configures the pod's default interface.
+func (csc *CniServerHandler) CmdAdd(req *PodRequest) error {
+	ip, mac, err := resolveStaticAddress(req)
+	if err != nil {
+		return err
+	}
+
+	mtu, err := resolveMTU(csc.Config.Mtu, req.SubnetMTU, req.Annotations[MTUAnnotation])
+	if err != nil {
+		return err
+	}
+
+	routes, err := mergeRoutes(req.SubnetRoutes, req.Annotations[RoutesAnnotation])
+	if err != nil {
+		return err
+	}
+
+	attachments, err := parseAttachNetworksAnnotation(req.Annotations[AttachNetworksAnnotation])
+	if err != nil {
+		return err
+	}
+	for i := range attachments {
+		if attachments[i].MTU <= 0 {
+			attachments[i].MTU = csc.Config.Mtu
+		}
+	}
+
+	sysctls, err := parseSysctlsAnnotation(req.Annotations[SysctlsAnnotation])
+	if err != nil {
+		return err
+	}
+
+	return csc.configureNic(req.PodName, req.PodNamespace, req.Netns, req.ContainerID, mac, ip, req.GatewayIP, req.DefaultGateway, routes, mtu, attachments, sysctls)
+}
+
+// resolveStaticAddress picks between the IPAM-allocated address and a
+// workload-requested static IP/MAC, preferring CNI_ARGS over the
+// equivalent annotation, and validates the request before letting it
+// override the allocated value.
+func resolveStaticAddress(req *PodRequest) (ip, mac string, err error) {
+	ip, mac = req.IP, req.Mac
+
+	requestedIP := req.CniArgsIP
+	if requestedIP == "" {
+		requestedIP = req.Annotations[IPAddressAnnotation]
+	}
+	requestedMAC := req.CniArgsMAC
+	if requestedMAC == "" {
+		requestedMAC = req.Annotations[MacAddressAnnotation]
+	}
+
+	if requestedIP == "" && requestedMAC == "" {
+		return ip, mac, nil
+	}
+
+	if err := validateStaticAddress(req.SubnetCIDR, requestedIP, requestedMAC); err != nil {
+		return "", "", err
+	}
+	if requestedIP != "" {
+		ip = requestedIP
+	}
+	if requestedMAC != "" {
+		mac = requestedMAC
+	}
+	return ip, mac, nil
+}
+
+// CmdDel tears down a pod's default interface plus any secondary
+// attachments requested via AttachNetworksAnnotation, mirroring CmdAdd's
+// attachment resolution so teardown cleans up exactly what ADD created.
+func (csc *CniServerHandler) CmdDel(netns, containerID string, annotations map[string]string) error {
+	attachments, err := parseAttachNetworksAnnotation(annotations[AttachNetworksAnnotation])
+	if err != nil {
+		return err
+	}
+	ifNames := make([]string, 0, len(attachments)+1)
+	ifNames = append(ifNames, "eth0")
+	for _, a := range attachments {
+		ifNames = append(ifNames, a.IfName)
+	}
+	return csc.deleteNic(netns, containerID, ifNames...)
+}
+
+// parseAttachNetworksAnnotation decodes the secondary network attachments
+// requested via AttachNetworksAnnotation.
+func parseAttachNetworksAnnotation(raw string) ([]Attachment, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var attachments []Attachment
+	if err := json.Unmarshal([]byte(raw), &attachments); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", AttachNetworksAnnotation, err)
+	}
+	return attachments, nil
+}
+
+// parseSysctlsAnnotation decodes the sysctls requested via
+// SysctlsAnnotation; applyPodSysctls does the actual key validation.
+func parseSysctlsAnnotation(raw string) (map[string]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var sysctls map[string]string
+	if err := json.Unmarshal([]byte(raw), &sysctls); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", SysctlsAnnotation, err)
+	}
+	return sysctls, nil
+}
+
+// resolveMTU applies, in increasing priority, the daemon default, the
+// subnet's own override and the pod's MTUAnnotation.
+func resolveMTU(daemonMTU, subnetMTU int, mtuAnnotation string) (int, error) {
+	mtu := daemonMTU
+	if subnetMTU > 0 {
+		mtu = subnetMTU
+	}
+	if mtuAnnotation != "" {
+		v, err := strconv.Atoi(mtuAnnotation)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s annotation %q: %v", MTUAnnotation, mtuAnnotation, err)
+		}
+		mtu = v
+	}
+	if mtu <= 0 {
+		mtu = defaultMTU
+	}
+	return mtu, nil
+}
+
+// mergeRoutes appends the routes requested via RoutesAnnotation to the
+// subnet's own static routes.
+func mergeRoutes(subnetRoutes []Route, routesAnnotation string) ([]Route, error) {
+	if routesAnnotation == "" {
+		return subnetRoutes, nil
+	}
+	var extra []Route
+	if err := json.Unmarshal([]byte(routesAnnotation), &extra); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", RoutesAnnotation, err)
+	}
+	return append(append([]Route{}, subnetRoutes...), extra...), nil
+}
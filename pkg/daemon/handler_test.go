@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveMTU(t *testing.T) {
+	cases := []struct {
+		name          string
+		daemonMTU     int
+		subnetMTU     int
+		mtuAnnotation string
+		want          int
+		wantErr       bool
+	}{
+		{name: "daemon default only", daemonMTU: 1400, want: 1400},
+		{name: "subnet overrides daemon default", daemonMTU: 1400, subnetMTU: 1450, want: 1450},
+		{name: "annotation overrides subnet and daemon default", daemonMTU: 1400, subnetMTU: 1450, mtuAnnotation: "1500", want: 1500},
+		{name: "annotation overrides daemon default with no subnet override", daemonMTU: 1400, mtuAnnotation: "1500", want: 1500},
+		{name: "non-positive daemon default falls back to defaultMTU", daemonMTU: 0, want: defaultMTU},
+		{name: "invalid annotation is an error", daemonMTU: 1400, mtuAnnotation: "not-a-number", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := resolveMTU(c.daemonMTU, c.subnetMTU, c.mtuAnnotation)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("resolveMTU(%d, %d, %q) = %d, want error", c.daemonMTU, c.subnetMTU, c.mtuAnnotation, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveMTU(%d, %d, %q) returned unexpected error: %v", c.daemonMTU, c.subnetMTU, c.mtuAnnotation, err)
+			}
+			if got != c.want {
+				t.Errorf("resolveMTU(%d, %d, %q) = %d, want %d", c.daemonMTU, c.subnetMTU, c.mtuAnnotation, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMergeRoutes(t *testing.T) {
+	subnetRoutes := []Route{{Destination: "10.0.0.0/16", Gateway: "10.0.0.1"}}
+
+	cases := []struct {
+		name             string
+		subnetRoutes     []Route
+		routesAnnotation string
+		want             []Route
+		wantErr          bool
+	}{
+		{
+			name:         "no annotation returns subnet routes unchanged",
+			subnetRoutes: subnetRoutes,
+			want:         subnetRoutes,
+		},
+		{
+			name:             "annotation routes are appended after subnet routes",
+			subnetRoutes:     subnetRoutes,
+			routesAnnotation: `[{"dst":"192.168.0.0/24","gw":"192.168.0.1"}]`,
+			want: []Route{
+				{Destination: "10.0.0.0/16", Gateway: "10.0.0.1"},
+				{Destination: "192.168.0.0/24", Gateway: "192.168.0.1"},
+			},
+		},
+		{
+			name:             "nil subnet routes with an annotation",
+			routesAnnotation: `[{"dst":"192.168.0.0/24","gw":"192.168.0.1"}]`,
+			want:             []Route{{Destination: "192.168.0.0/24", Gateway: "192.168.0.1"}},
+		},
+		{
+			name:             "invalid annotation is an error",
+			subnetRoutes:     subnetRoutes,
+			routesAnnotation: `not-json`,
+			wantErr:          true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := mergeRoutes(c.subnetRoutes, c.routesAnnotation)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("mergeRoutes(%v, %q) = %v, want error", c.subnetRoutes, c.routesAnnotation, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeRoutes(%v, %q) returned unexpected error: %v", c.subnetRoutes, c.routesAnnotation, err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("mergeRoutes(%v, %q) = %v, want %v", c.subnetRoutes, c.routesAnnotation, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseAttachNetworksAnnotation(t *testing.T) {
+	cases := []struct {
+		name    string
+		raw     string
+		want    []Attachment
+		wantErr bool
+	}{
+		{name: "empty annotation returns nil", raw: "", want: nil},
+		{
+			name: "single attachment",
+			raw:  `[{"ifName":"net1","switch":"ovn-default","defaultGateway":false}]`,
+			want: []Attachment{{IfName: "net1", Switch: "ovn-default"}},
+		},
+		{
+			name: "multiple attachments preserve order",
+			raw:  `[{"ifName":"net1","switch":"sw1"},{"ifName":"net2","switch":"sw2","defaultGateway":true}]`,
+			want: []Attachment{
+				{IfName: "net1", Switch: "sw1"},
+				{IfName: "net2", Switch: "sw2", DefaultGateway: true},
+			},
+		},
+		{name: "invalid json is an error", raw: `not-json`, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := parseAttachNetworksAnnotation(c.raw)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("parseAttachNetworksAnnotation(%q) = %v, want error", c.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAttachNetworksAnnotation(%q) returned unexpected error: %v", c.raw, err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("parseAttachNetworksAnnotation(%q) = %+v, want %+v", c.raw, got, c.want)
+			}
+		})
+	}
+}
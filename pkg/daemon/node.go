@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net"
+	"os/exec"
+
+	"github.com/vishvananda/netlink"
+)
+
+// nodeOVSInternalPort is the name of the OVS internal interface that gives
+// the node itself a leg on br-int, so host-originated traffic (kube-proxy,
+// kubelet probes, hostNetwork pods talking to ClusterIPs) can take the OVN
+// datapath instead of hairpinning through the underlay.
+const nodeOVSInternalPort = "ovn0"
+
+// setupNodePort provisions the node's ovn0 internal port: it creates the OVS
+// port on br-int, assigns it nodeIP from the join subnet, brings it up,
+// routes the cluster pod CIDR over it and installs a MASQUERADE rule for
+// pod->external traffic leaving via this node. If nodeMAC is empty, a
+// deterministic locally-administered MAC is derived from nodeName so the
+// node gets the same address across restarts. It returns the MAC/IP actually
+// used so the caller can report them to the central controller, which
+// creates the matching logical switch port on the node-join switch.
+func (csc CniServerHandler) setupNodePort(nodeName, nodeIP, nodeMAC, joinSubnetCIDR, podCIDR string) (string, string, error) {
+	if nodeMAC == "" {
+		nodeMAC = deterministicNodeMAC(nodeName)
+	}
+	macAddr, err := net.ParseMAC(nodeMAC)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid node mac %s %v", nodeMAC, err)
+	}
+
+	addr, err := nodeJoinAddr(nodeIP, joinSubnetCIDR)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = csc.ovsAddPort(nodeOVSInternalPort, "internal", map[string]string{"iface-id": "node-" + nodeName}); err != nil {
+		return "", "", err
+	}
+
+	link, err := netlink.LinkByName(nodeOVSInternalPort)
+	if err != nil {
+		return "", "", fmt.Errorf("can not find node nic %s %v", nodeOVSInternalPort, err)
+	}
+	if err = netlink.LinkSetHardwareAddr(link, macAddr); err != nil {
+		return "", "", fmt.Errorf("can not set mac address on %s %v", nodeOVSInternalPort, err)
+	}
+	if err = netlink.AddrReplace(link, addr); err != nil {
+		return "", "", fmt.Errorf("can not set address on %s %v", nodeOVSInternalPort, err)
+	}
+	if err = netlink.LinkSetUp(link); err != nil {
+		return "", "", fmt.Errorf("can not set %s up %v", nodeOVSInternalPort, err)
+	}
+
+	if _, podDst, err := net.ParseCIDR(podCIDR); err == nil {
+		route := &netlink.Route{LinkIndex: link.Attrs().Index, Dst: podDst, Scope: netlink.SCOPE_LINK}
+		if err = netlink.RouteReplace(route); err != nil {
+			return "", "", fmt.Errorf("can not add pod cidr route via %s %v", nodeOVSInternalPort, err)
+		}
+	} else {
+		return "", "", fmt.Errorf("invalid pod cidr %s %v", podCIDR, err)
+	}
+
+	if err = ensureMasqueradeRule(podCIDR, nodeOVSInternalPort); err != nil {
+		return "", "", err
+	}
+
+	return macAddr.String(), addr.IPNet.String(), nil
+}
+
+// nodeJoinAddr parses nodeIP as a host address on joinSubnetCIDR, keeping
+// the join subnet's prefix length so it's installed as a connected route.
+// It goes through netlink.ParseAddr, like configureContainerNic does for pod
+// addresses, rather than building a net.IPNet by hand: net.ParseIP always
+// returns a 16-byte slice even for an IPv4 address, which paired with
+// net.CIDRMask(ones, 32) produces a mismatched-length IPNet.
+func nodeJoinAddr(nodeIP, joinSubnetCIDR string) (*netlink.Addr, error) {
+	_, joinNet, err := net.ParseCIDR(joinSubnetCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid join subnet cidr %s %v", joinSubnetCIDR, err)
+	}
+	ones, _ := joinNet.Mask.Size()
+	addr, err := netlink.ParseAddr(fmt.Sprintf("%s/%d", nodeIP, ones))
+	if err != nil {
+		return nil, fmt.Errorf("invalid node ip %s %v", nodeIP, err)
+	}
+	return addr, nil
+}
+
+// deterministicNodeMAC derives a locally-administered MAC from nodeName so
+// ovn0 keeps the same address across daemon restarts without needing
+// persistent state.
+func deterministicNodeMAC(nodeName string) string {
+	sum := sha1.Sum([]byte(nodeName))
+	// Set the locally-administered bit and clear the multicast bit on the
+	// first octet, per IEEE 802.
+	sum[0] = (sum[0] | 0x02) & 0xfe
+	mac := net.HardwareAddr(sum[0:6])
+	return mac.String()
+}
+
+// ensureMasqueradeRule installs a POSTROUTING MASQUERADE rule for traffic
+// leaving podCIDR via a different interface than ovn0 (i.e. pod->external),
+// idempotently so repeated daemon starts don't pile up duplicate rules.
+func ensureMasqueradeRule(podCIDR, outIface string) error {
+	checkArgs := []string{"-t", "nat", "-C", "POSTROUTING", "-s", podCIDR, "!", "-o", outIface, "-j", "MASQUERADE"}
+	if err := exec.Command("iptables", checkArgs...).Run(); err == nil {
+		return nil
+	}
+
+	addArgs := []string{"-t", "nat", "-A", "POSTROUTING", "-s", podCIDR, "!", "-o", outIface, "-j", "MASQUERADE"}
+	if output, err := exec.Command("iptables", addArgs...).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to add masquerade rule for %s %v: %s", podCIDR, err, output)
+	}
+	return nil
+}
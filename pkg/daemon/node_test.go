@@ -0,0 +1,65 @@
+package daemon
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNodeJoinAddr(t *testing.T) {
+	cases := []struct {
+		name           string
+		nodeIP         string
+		joinSubnetCIDR string
+		want           string
+		wantErr        bool
+	}{
+		{name: "ipv4 keeps the join subnet's prefix length", nodeIP: "100.64.0.2", joinSubnetCIDR: "100.64.0.0/16", want: "100.64.0.2/16"},
+		{name: "ipv6", nodeIP: "fd00::2", joinSubnetCIDR: "fd00::/64", want: "fd00::2/64"},
+		{name: "invalid join subnet cidr is an error", nodeIP: "100.64.0.2", joinSubnetCIDR: "not-a-cidr", wantErr: true},
+		{name: "invalid node ip is an error", nodeIP: "not-an-ip", joinSubnetCIDR: "100.64.0.0/16", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			addr, err := nodeJoinAddr(c.nodeIP, c.joinSubnetCIDR)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("nodeJoinAddr(%q, %q) = %v, want error", c.nodeIP, c.joinSubnetCIDR, addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("nodeJoinAddr(%q, %q) returned unexpected error: %v", c.nodeIP, c.joinSubnetCIDR, err)
+			}
+			if got := addr.String(); got != c.want {
+				t.Errorf("nodeJoinAddr(%q, %q) = %q, want %q", c.nodeIP, c.joinSubnetCIDR, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDeterministicNodeMAC(t *testing.T) {
+	mac1 := deterministicNodeMAC("node-a")
+	mac2 := deterministicNodeMAC("node-b")
+
+	if mac1 == "" {
+		t.Fatal("deterministicNodeMAC returned an empty string")
+	}
+	if mac1 != deterministicNodeMAC("node-a") {
+		t.Errorf("deterministicNodeMAC(%q) is not deterministic: %q vs %q", "node-a", mac1, deterministicNodeMAC("node-a"))
+	}
+	if mac1 == mac2 {
+		t.Errorf("deterministicNodeMAC produced the same MAC for different node names: %q", mac1)
+	}
+
+	firstByte, err := strconv.ParseUint(mac1[0:2], 16, 8)
+	if err != nil {
+		t.Fatalf("deterministicNodeMAC(%q) = %q, first octet not hex: %v", "node-a", mac1, err)
+	}
+	if firstByte&0x02 == 0 {
+		t.Errorf("deterministicNodeMAC(%q) = %q, locally-administered bit not set on first octet", "node-a", mac1)
+	}
+	if firstByte&0x01 != 0 {
+		t.Errorf("deterministicNodeMAC(%q) = %q, multicast bit set on first octet", "node-a", mac1)
+	}
+}
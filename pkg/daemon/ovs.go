@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
@@ -8,14 +10,54 @@ import (
 	"os/exec"
 )
 
-func (csc CniServerHandler) configureNic(podName, podNamespace, netns, containerID, mac, ip string) error {
+func (csc CniServerHandler) configureNic(podName, podNamespace, netns, containerID, mac, ip, gatewayIP string, defaultGateway bool, routes []Route, mtu int, attachments []Attachment, sysctls map[string]string) error {
+	defaultGateways := 0
+	if defaultGateway {
+		defaultGateways++
+	}
+	for _, a := range attachments {
+		if a.DefaultGateway {
+			defaultGateways++
+		}
+	}
+	if defaultGateways > 1 {
+		return fmt.Errorf("only one attached network may be marked as the default gateway provider, got %d", defaultGateways)
+	}
+
+	if err := csc.attachInterface(podName, podNamespace, netns, containerID, "eth0", "", mac, ip, gatewayIP, defaultGateway, routes, mtu); err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		if err := csc.attachInterface(podName, podNamespace, netns, containerID, a.IfName, a.Switch, a.Mac, a.IP, a.Gateway, a.DefaultGateway, a.Routes, a.MTU); err != nil {
+			return err
+		}
+	}
+
+	if len(sysctls) != 0 {
+		if err := ns.WithNetNSPath(netns, func(_ ns.NetNS) error {
+			return applyPodSysctls(sysctls)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachInterface wires a single interface, named ifName inside the pod
+// netns, to the logical switch switchName (empty for the pod's default
+// network). It creates a veth pair, adds the host end to br-int and moves
+// the container end into the pod's netns.
+func (csc CniServerHandler) attachInterface(podName, podNamespace, netns, containerID, ifName, switchName, mac, ip, gatewayIP string, defaultGateway bool, routes []Route, mtu int) error {
 	var err error
-	hostNicName, containerNicName := generateNicName(containerID)
+	hostNicName, containerNicName := generateNicName(containerID, ifName)
 
 	// Create a veth pair, put one end to container ,the other to ovs port
 	// NOTE: DO NOT use ovs internal type interface for container.
-	// Kubernetes will detect 'eth0' nic in pod, so the nic name in pod must be 'eth0'.
-	// When renaming internal interface to 'eth0', ovs will delete and recreate this interface.
+	// Kubernetes will detect 'eth0' nic in pod, so the default interface
+	// name in pod must be 'eth0'; secondary attachments use the name
+	// requested via the attach-networks annotation.
+	// When renaming internal interface, ovs will delete and recreate this interface.
 	veth := netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: hostNicName}, PeerName: containerNicName}
 	defer func() {
 		// Remove veth link in case any error during creating pod network.
@@ -29,9 +71,12 @@ func (csc CniServerHandler) configureNic(podName, podNamespace, netns, container
 	}
 
 	// Add veth pair host end to ovs port
-	output, err := exec.Command("ovs-vsctl", "add-port", "br-int", hostNicName, "--", "set", "interface", hostNicName, fmt.Sprintf("external_ids:iface-id=%s.%s", podName, podNamespace)).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("add nic to ovs failed %v: %s", err, output)
+	externalIDs := map[string]string{"iface-id": ifaceID(podName, podNamespace, ifName)}
+	if switchName != "" {
+		externalIDs["attach-network"] = switchName
+	}
+	if err = csc.ovsAddPort(hostNicName, "", externalIDs); err != nil {
+		return err
 	}
 
 	// host and container nic must use same mac address, otherwise ovn will reject these packets by default
@@ -40,7 +85,11 @@ func (csc CniServerHandler) configureNic(podName, podNamespace, netns, container
 		return fmt.Errorf("failed to parse mac %s %v", macAddr, err)
 	}
 
-	err = configureHostNic(hostNicName, macAddr)
+	if mtu <= 0 {
+		mtu = defaultMTU
+	}
+
+	err = configureHostNic(hostNicName, macAddr, mtu)
 	if err != nil {
 		return err
 	}
@@ -49,7 +98,7 @@ func (csc CniServerHandler) configureNic(podName, podNamespace, netns, container
 	if err != nil {
 		return fmt.Errorf("failed to open netns %q: %v", netns, err)
 	}
-	err = configureContainerNic(containerNicName, ip, macAddr, podNS)
+	err = configureContainerNic(containerNicName, ifName, ip, gatewayIP, defaultGateway, routes, macAddr, mtu, podNS)
 	if err != nil {
 		return err
 	}
@@ -57,13 +106,72 @@ func (csc CniServerHandler) configureNic(podName, podNamespace, netns, container
 	return nil
 }
 
-func (csc CniServerHandler) deleteNic(netns, containerID string) error {
-	hostNicName, _ := generateNicName(containerID)
-	// Remove ovs port
-	output, err := exec.Command("ovs-vsctl", "--if-exists", "--with-iface", "del-port", "br-int", hostNicName).CombinedOutput()
+// ovsAddPort adds a port to br-int, using the persistent OVSDB connection
+// when available and falling back to ovs-vsctl (--enable-ovsdb-client=false,
+// or no OVSDB client configured) for debugging. ifaceType is "" for a plain
+// veth/system port, or "internal" for an OVS-owned port such as the node's
+// ovn0 internal interface.
+func (csc CniServerHandler) ovsAddPort(name, ifaceType string, externalIDs map[string]string) error {
+	if csc.ovsClient != nil {
+		return csc.ovsClient.AddPort("br-int", name, ifaceType, externalIDs)
+	}
+
+	args := []string{"add-port", "br-int", name, "--", "set", "interface", name}
+	if ifaceType != "" {
+		args = append(args, fmt.Sprintf("type=%s", ifaceType))
+	}
+	for k, v := range externalIDs {
+		args = append(args, fmt.Sprintf("external_ids:%s=%s", k, v))
+	}
+	output, err := exec.Command("ovs-vsctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("add nic to ovs failed %v: %s", err, output)
+	}
+	return nil
+}
+
+// ovsDelPort mirrors ovsAddPort's OVSDB-client/ovs-vsctl split for port
+// removal. It is a no-op, not an error, if the port does not exist.
+func (csc CniServerHandler) ovsDelPort(name string) error {
+	if csc.ovsClient != nil {
+		return csc.ovsClient.DelPort("br-int", name)
+	}
+
+	output, err := exec.Command("ovs-vsctl", "--if-exists", "--with-iface", "del-port", "br-int", name).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to delete ovs port %v, %s", err, output)
 	}
+	return nil
+}
+
+// ifaceID is the external_ids:iface-id value kube-ovn uses to bind an OVS
+// port to a logical switch port. Secondary attachments suffix the pod's
+// default "<pod>.<namespace>" id with the requested interface name so each
+// attachment gets a distinct, deterministic id.
+func ifaceID(podName, podNamespace, ifName string) string {
+	if ifName == "" || ifName == "eth0" {
+		return fmt.Sprintf("%s.%s", podName, podNamespace)
+	}
+	return fmt.Sprintf("%s.%s.%s", podName, podNamespace, ifName)
+}
+
+func (csc CniServerHandler) deleteNic(netns, containerID string, ifNames ...string) error {
+	if len(ifNames) == 0 {
+		ifNames = []string{"eth0"}
+	}
+	for _, ifName := range ifNames {
+		if err := csc.deleteInterface(containerID, ifName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (csc CniServerHandler) deleteInterface(containerID, ifName string) error {
+	hostNicName, _ := generateNicName(containerID, ifName)
+	if err := csc.ovsDelPort(hostNicName); err != nil {
+		return err
+	}
 
 	hostLink, err := netlink.LinkByName(hostNicName)
 	if err != nil {
@@ -80,11 +188,22 @@ func (csc CniServerHandler) deleteNic(netns, containerID string) error {
 	return nil
 }
 
-func generateNicName(containerID string) (string, string) {
-	return fmt.Sprintf("%s_h", containerID[0:12]), fmt.Sprintf("%s_c", containerID[0:12])
+// generateNicName returns deterministic host/container veth names for a
+// given (containerID, ifName) pair. The default eth0 interface keeps the
+// original short form so existing OVS ports survive an upgrade; secondary
+// attachments are named from a hash of containerID+ifName since a pod can
+// have more than one interface and IFNAMSIZ leaves no room to encode both
+// the container id and an arbitrary interface name.
+func generateNicName(containerID, ifName string) (string, string) {
+	if ifName == "" || ifName == "eth0" {
+		return fmt.Sprintf("%s_h", containerID[0:12]), fmt.Sprintf("%s_c", containerID[0:12])
+	}
+	h := sha1.Sum([]byte(containerID + ifName))
+	short := hex.EncodeToString(h[:])[:8]
+	return fmt.Sprintf("%s_h", short), fmt.Sprintf("%s_c", short)
 }
 
-func configureHostNic(nicName string, macAddr net.HardwareAddr) error {
+func configureHostNic(nicName string, macAddr net.HardwareAddr, mtu int) error {
 	hostLink, err := netlink.LinkByName(nicName)
 	if err != nil {
 		return fmt.Errorf("can not find host nic %s %v", nicName, err)
@@ -94,6 +213,9 @@ func configureHostNic(nicName string, macAddr net.HardwareAddr) error {
 	if err != nil {
 		return fmt.Errorf("can not set mac address to host nic %s %v", nicName, err)
 	}
+	if err = netlink.LinkSetMTU(hostLink, mtu); err != nil {
+		return fmt.Errorf("can not set mtu on host nic %s %v", nicName, err)
+	}
 	err = netlink.LinkSetUp(hostLink)
 	if err != nil {
 		return fmt.Errorf("can not set host nic %s up %v", nicName, err)
@@ -101,7 +223,7 @@ func configureHostNic(nicName string, macAddr net.HardwareAddr) error {
 	return nil
 }
 
-func configureContainerNic(nicName, ipAddr string, macaddr net.HardwareAddr, netns ns.NetNS) error {
+func configureContainerNic(nicName, ifName, ipAddr, gatewayIP string, defaultGateway bool, routes []Route, macaddr net.HardwareAddr, mtu int, netns ns.NetNS) error {
 	containerLink, err := netlink.LinkByName(nicName)
 	if err != nil {
 		return fmt.Errorf("can not find container nic %s %v", nicName, err)
@@ -112,12 +234,21 @@ func configureContainerNic(nicName, ipAddr string, macaddr net.HardwareAddr, net
 		return fmt.Errorf("failed to link netns %v", err)
 	}
 
+	if ifName == "" {
+		ifName = "eth0"
+	}
+
 	return ns.WithNetNSPath(netns.Path(), func(_ ns.NetNS) error {
-		// Container nic name MUST be 'eth0', otherwise kubelet will recreate the pod
-		err = netlink.LinkSetName(containerLink, "eth0")
+		// The default interface name MUST be 'eth0', otherwise kubelet will
+		// recreate the pod; secondary attachments use the name requested
+		// via the attach-networks annotation.
+		err = netlink.LinkSetName(containerLink, ifName)
 		if err != nil {
 			return err
 		}
+		if err = netlink.LinkSetMTU(containerLink, mtu); err != nil {
+			return fmt.Errorf("can not set mtu on container nic %v", err)
+		}
 		addr, err := netlink.ParseAddr(ipAddr)
 		if err != nil {
 			return fmt.Errorf("can not parse %s %v", ipAddr, err)
@@ -135,6 +266,90 @@ func configureContainerNic(nicName, ipAddr string, macaddr net.HardwareAddr, net
 		if err != nil {
 			return fmt.Errorf("can not set container nic %s up %v", nicName, err)
 		}
+
+		if defaultGateway {
+			if err = addDefaultGateway(containerLink, gatewayIP, addr); err != nil {
+				return err
+			}
+		}
+		for _, route := range routes {
+			if err = addExtraRoute(containerLink, route); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 }
+
+// addDefaultGateway installs a default route via gatewayIP on link. If
+// gatewayIP does not fall inside the address just assigned to link, an
+// on-link host route to the gateway is added first so the kernel accepts it
+// as a next hop.
+func addDefaultGateway(link netlink.Link, gatewayIP string, addr *netlink.Addr) error {
+	gw, needsOnLink, err := gatewayReachability(gatewayIP, addr)
+	if err != nil {
+		return err
+	}
+
+	if needsOnLink {
+		bits := onLinkMaskBits(gw)
+		onLink := &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Dst:       &net.IPNet{IP: gw, Mask: net.CIDRMask(bits, bits)},
+			Scope:     netlink.SCOPE_LINK,
+		}
+		if err := netlink.RouteAdd(onLink); err != nil {
+			return fmt.Errorf("failed to add on-link route to gateway %s %v", gatewayIP, err)
+		}
+	}
+
+	defaultRoute := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Gw:        gw,
+	}
+	if err := netlink.RouteAdd(defaultRoute); err != nil {
+		return fmt.Errorf("failed to add default route via %s %v", gatewayIP, err)
+	}
+	return nil
+}
+
+// onLinkMaskBits returns the mask width for the on-link host route added to
+// reach an off-link gateway: 32 for an IPv4 gw, 128 for IPv6. gw is always a
+// 16-byte net.IP, even for an IPv4 address, so the width must be picked from
+// the address family rather than hardcoded; see nodeJoinAddr for the same
+// mismatched-length-IPNet pitfall.
+func onLinkMaskBits(gw net.IP) int {
+	if gw.To4() != nil {
+		return 32
+	}
+	return 128
+}
+
+// gatewayReachability parses gatewayIP and reports whether it falls outside
+// addr, the address just assigned to the pod's link. When it does,
+// addDefaultGateway must add an on-link host route to the gateway before
+// the kernel will accept a default route via it as a next hop.
+func gatewayReachability(gatewayIP string, addr *netlink.Addr) (gw net.IP, needsOnLink bool, err error) {
+	gw = net.ParseIP(gatewayIP)
+	if gw == nil {
+		return nil, false, fmt.Errorf("invalid gateway address %s", gatewayIP)
+	}
+	return gw, addr == nil || !addr.Contains(gw), nil
+}
+
+// addExtraRoute installs a single static route sourced from the subnet spec
+// or the RoutesAnnotation.
+func addExtraRoute(link netlink.Link, route Route) error {
+	_, dst, err := net.ParseCIDR(route.Destination)
+	if err != nil {
+		return fmt.Errorf("invalid route destination %s %v", route.Destination, err)
+	}
+	gw := net.ParseIP(route.Gateway)
+	if gw == nil {
+		return fmt.Errorf("invalid route gateway %s", route.Gateway)
+	}
+	if err = netlink.RouteAdd(&netlink.Route{LinkIndex: link.Attrs().Index, Dst: dst, Gw: gw}); err != nil {
+		return fmt.Errorf("failed to add route %s via %s %v", route.Destination, route.Gateway, err)
+	}
+	return nil
+}
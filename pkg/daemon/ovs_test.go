@@ -0,0 +1,124 @@
+package daemon
+
+import (
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestGatewayReachability(t *testing.T) {
+	addr, err := netlink.ParseAddr("10.0.0.5/24")
+	if err != nil {
+		t.Fatalf("ParseAddr: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		gatewayIP  string
+		addr       *netlink.Addr
+		wantOnLink bool
+		wantErr    bool
+	}{
+		{name: "gateway inside assigned subnet", gatewayIP: "10.0.0.1", addr: addr, wantOnLink: false},
+		{name: "gateway outside assigned subnet needs on-link route", gatewayIP: "192.168.1.1", addr: addr, wantOnLink: true},
+		{name: "nil addr always needs on-link route", gatewayIP: "10.0.0.1", addr: nil, wantOnLink: true},
+		{name: "invalid gateway is an error", gatewayIP: "not-an-ip", addr: addr, wantErr: true},
+		{name: "IPv6 gateway outside assigned subnet needs on-link route", gatewayIP: "2001:db8:1::1", addr: addr, wantOnLink: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gw, needsOnLink, err := gatewayReachability(c.gatewayIP, c.addr)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("gatewayReachability(%q, ...) = %v, %v, want error", c.gatewayIP, gw, needsOnLink)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("gatewayReachability(%q, ...) returned unexpected error: %v", c.gatewayIP, err)
+			}
+			if needsOnLink != c.wantOnLink {
+				t.Errorf("gatewayReachability(%q, ...) needsOnLink = %v, want %v", c.gatewayIP, needsOnLink, c.wantOnLink)
+			}
+			if !gw.Equal(net.ParseIP(c.gatewayIP)) {
+				t.Errorf("gatewayReachability(%q, ...) gw = %v, want %v", c.gatewayIP, gw, c.gatewayIP)
+			}
+		})
+	}
+}
+
+func TestOnLinkMaskBits(t *testing.T) {
+	cases := []struct {
+		name string
+		gw   string
+		want int
+	}{
+		{name: "IPv4 gateway gets a /32", gw: "192.168.1.1", want: 32},
+		{name: "IPv6 gateway gets a /128", gw: "2001:db8:1::1", want: 128},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gw := net.ParseIP(c.gw)
+			if got := onLinkMaskBits(gw); got != c.want {
+				t.Errorf("onLinkMaskBits(%v) = %d, want %d", gw, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIfaceID(t *testing.T) {
+	cases := []struct {
+		name         string
+		podName      string
+		podNamespace string
+		ifName       string
+		want         string
+	}{
+		{name: "empty ifName is the default interface", podName: "web", podNamespace: "default", ifName: "", want: "web.default"},
+		{name: "eth0 is the default interface", podName: "web", podNamespace: "default", ifName: "eth0", want: "web.default"},
+		{name: "secondary attachment suffixes the ifName", podName: "web", podNamespace: "default", ifName: "net1", want: "web.default.net1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := ifaceID(c.podName, c.podNamespace, c.ifName); got != c.want {
+				t.Errorf("ifaceID(%q, %q, %q) = %q, want %q", c.podName, c.podNamespace, c.ifName, got, c.want)
+			}
+		})
+	}
+}
+
+func TestGenerateNicName(t *testing.T) {
+	containerID := "abcdef0123456789abcdef0123456789abcdef0123456789abcdef012345678"
+
+	t.Run("eth0 keeps the short container-id form", func(t *testing.T) {
+		host, container := generateNicName(containerID, "eth0")
+		if want := "abcdef012345_h"; host != want {
+			t.Errorf("host nic name = %q, want %q", host, want)
+		}
+		if want := "abcdef012345_c"; container != want {
+			t.Errorf("container nic name = %q, want %q", container, want)
+		}
+	})
+
+	t.Run("empty ifName is treated as eth0", func(t *testing.T) {
+		host, _ := generateNicName(containerID, "")
+		if want := "abcdef012345_h"; host != want {
+			t.Errorf("host nic name = %q, want %q", host, want)
+		}
+	})
+
+	t.Run("secondary attachment is hashed and distinct per ifName", func(t *testing.T) {
+		host1, container1 := generateNicName(containerID, "net1")
+		host2, container2 := generateNicName(containerID, "net2")
+		if host1 == host2 || container1 == container2 {
+			t.Fatalf("expected distinct names for net1/net2, got %q/%q and %q/%q", host1, container1, host2, container2)
+		}
+		if host1 == "abcdef012345_h" {
+			t.Errorf("secondary attachment must not reuse eth0's short form, got %q", host1)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Server is the CNI daemon's HTTP API. The CNI binary kubelet invokes for
+// each ADD/DEL talks to it over a local socket and is the real caller of
+// CmdAdd/CmdDel.
+type Server struct {
+	handler *CniServerHandler
+}
+
+// NewServer builds a Server around handler.
+func NewServer(handler *CniServerHandler) *Server {
+	return &Server{handler: handler}
+}
+
+// Bind registers the server's routes on mux.
+func (s *Server) Bind(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/add", s.handleAdd)
+	mux.HandleFunc("/api/v1/del", s.handleDel)
+}
+
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	var req PodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.handler.CmdAdd(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type delRequest struct {
+	Netns       string            `json:"netns"`
+	ContainerID string            `json:"containerID"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func (s *Server) handleDel(w http.ResponseWriter, r *http.Request) {
+	var req delRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.handler.CmdDel(req.Netns, req.ContainerID, req.Annotations); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,45 @@
+package daemon
+
+import (
+	"fmt"
+	"net"
+)
+
+// validateStaticAddress checks a statically requested IP/MAC (sourced from
+// CNI_ARGS "IP"/"MAC" or the IPAddressAnnotation/MacAddressAnnotation)
+// before it overrides the allocated address and is handed to configureNic.
+// The requested address is not reserved in the IPAM store. It only rejects
+// requests that can never be valid (malformed, or outside the subnet); it
+// does not check the requested address against other pods' allocations.
+//
+// TODO(chunk0-4 followup): reject a requested address that collides with
+// one already allocated to another pod. That needs a read path into the
+// IPAM store, which this package doesn't have yet.
+func validateStaticAddress(subnetCIDR, requestedIP, requestedMAC string) error {
+	var bad []string
+
+	if requestedMAC != "" {
+		if _, err := net.ParseMAC(requestedMAC); err != nil {
+			bad = append(bad, fmt.Sprintf("mac_address %q is not a valid MAC: %v", requestedMAC, err))
+		}
+	}
+
+	if requestedIP != "" {
+		ip := net.ParseIP(requestedIP)
+		if ip == nil {
+			bad = append(bad, fmt.Sprintf("ip_address %q is not a valid IP", requestedIP))
+		} else if subnetCIDR != "" {
+			_, cidr, err := net.ParseCIDR(subnetCIDR)
+			if err != nil {
+				bad = append(bad, fmt.Sprintf("subnet cidr %q is invalid: %v", subnetCIDR, err))
+			} else if !cidr.Contains(ip) {
+				bad = append(bad, fmt.Sprintf("ip_address %q is not within subnet %s", requestedIP, subnetCIDR))
+			}
+		}
+	}
+
+	if len(bad) != 0 {
+		return fmt.Errorf("invalid static address request: %v", bad)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import "testing"
+
+func TestValidateStaticAddress(t *testing.T) {
+	cases := []struct {
+		name         string
+		subnetCIDR   string
+		requestedIP  string
+		requestedMAC string
+		wantErr      bool
+	}{
+		{name: "valid IP and MAC", subnetCIDR: "10.0.0.0/24", requestedIP: "10.0.0.5", requestedMAC: "aa:bb:cc:dd:ee:ff"},
+		{name: "malformed IP", subnetCIDR: "10.0.0.0/24", requestedIP: "not-an-ip", wantErr: true},
+		{name: "malformed MAC", subnetCIDR: "10.0.0.0/24", requestedMAC: "not-a-mac", wantErr: true},
+		{name: "IP outside subnet CIDR", subnetCIDR: "10.0.0.0/24", requestedIP: "10.0.1.5", wantErr: true},
+		{name: "empty subnet CIDR skips containment check", requestedIP: "10.0.1.5"},
+		{name: "no request is a no-op", subnetCIDR: "10.0.0.0/24"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateStaticAddress(c.subnetCIDR, c.requestedIP, c.requestedMAC)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("validateStaticAddress(%q, %q, %q) = nil, want error", c.subnetCIDR, c.requestedIP, c.requestedMAC)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateStaticAddress(%q, %q, %q) returned unexpected error: %v", c.subnetCIDR, c.requestedIP, c.requestedMAC, err)
+			}
+		})
+	}
+}
+
+func TestResolveStaticAddress(t *testing.T) {
+	cases := []struct {
+		name    string
+		req     *PodRequest
+		wantIP  string
+		wantMAC string
+		wantErr bool
+	}{
+		{
+			name:    "no static request returns the allocated address",
+			req:     &PodRequest{IP: "10.0.0.2", Mac: "00:00:00:00:00:01", SubnetCIDR: "10.0.0.0/24"},
+			wantIP:  "10.0.0.2",
+			wantMAC: "00:00:00:00:00:01",
+		},
+		{
+			name: "CNI_ARGS IP takes priority over annotation",
+			req: &PodRequest{
+				IP:         "10.0.0.2",
+				Mac:        "00:00:00:00:00:01",
+				SubnetCIDR: "10.0.0.0/24",
+				CniArgsIP:  "10.0.0.5",
+				Annotations: map[string]string{
+					IPAddressAnnotation: "10.0.0.9",
+				},
+			},
+			wantIP:  "10.0.0.5",
+			wantMAC: "00:00:00:00:00:01",
+		},
+		{
+			name: "annotation IP is used when CNI_ARGS is unset",
+			req: &PodRequest{
+				IP:         "10.0.0.2",
+				Mac:        "00:00:00:00:00:01",
+				SubnetCIDR: "10.0.0.0/24",
+				Annotations: map[string]string{
+					IPAddressAnnotation: "10.0.0.9",
+				},
+			},
+			wantIP:  "10.0.0.9",
+			wantMAC: "00:00:00:00:00:01",
+		},
+		{
+			name: "CNI_ARGS MAC takes priority over annotation",
+			req: &PodRequest{
+				IP:         "10.0.0.2",
+				Mac:        "00:00:00:00:00:01",
+				SubnetCIDR: "10.0.0.0/24",
+				CniArgsMAC: "aa:aa:aa:aa:aa:aa",
+				Annotations: map[string]string{
+					MacAddressAnnotation: "bb:bb:bb:bb:bb:bb",
+				},
+			},
+			wantIP:  "10.0.0.2",
+			wantMAC: "aa:aa:aa:aa:aa:aa",
+		},
+		{
+			name: "annotation MAC is used when CNI_ARGS is unset",
+			req: &PodRequest{
+				IP:         "10.0.0.2",
+				Mac:        "00:00:00:00:00:01",
+				SubnetCIDR: "10.0.0.0/24",
+				Annotations: map[string]string{
+					MacAddressAnnotation: "bb:bb:bb:bb:bb:bb",
+				},
+			},
+			wantIP:  "10.0.0.2",
+			wantMAC: "bb:bb:bb:bb:bb:bb",
+		},
+		{
+			name: "invalid static request is an error",
+			req: &PodRequest{
+				IP:         "10.0.0.2",
+				Mac:        "00:00:00:00:00:01",
+				SubnetCIDR: "10.0.0.0/24",
+				CniArgsIP:  "10.0.1.5",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ip, mac, err := resolveStaticAddress(c.req)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("resolveStaticAddress(%+v) = (%q, %q, nil), want error", c.req, ip, mac)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveStaticAddress(%+v) returned unexpected error: %v", c.req, err)
+			}
+			if ip != c.wantIP || mac != c.wantMAC {
+				t.Errorf("resolveStaticAddress(%+v) = (%q, %q), want (%q, %q)", c.req, ip, mac, c.wantIP, c.wantMAC)
+			}
+		})
+	}
+}
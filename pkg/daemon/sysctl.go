@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sysctlNetPrefix is the only /proc/sys subtree pod sysctls may touch.
+// Anything outside it (e.g. vm.*, kernel.*) can affect the whole node, not
+// just the pod's netns, so it is rejected the same way the upstream tuning
+// CNI guards its allowlist.
+const sysctlNetPrefix = "/proc/sys/net/"
+
+// sysctlKeyPattern matches a dotted sysctl key such as
+// "net.ipv4.conf.eth0.rp_filter". Anything else - slashes, "..", leading
+// dots - is rejected before it ever reaches path construction, since
+// sysctlPath's dot-to-slash replacement would otherwise let a key like
+// "net/../../../etc/cron.d/x" resolve outside sysctlNetPrefix once the
+// kernel/filesystem collapses the "..", even though the unresolved string
+// still starts with "/proc/sys/net/".
+var sysctlKeyPattern = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)+$`)
+
+// SysctlError reports the sysctl keys that were rejected because they fall
+// outside sysctlNetPrefix, so kubelet can surface a useful event instead of
+// a generic "failed to configure pod network".
+type SysctlError struct {
+	Keys []string
+}
+
+func (e *SysctlError) Error() string {
+	return fmt.Sprintf("sysctls must be under net.*, rejected: %s", strings.Join(e.Keys, ", "))
+}
+
+// applyPodSysctls writes each dotted sysctl key (sourced from the
+// SysctlsAnnotation) to its /proc/sys path. It must be called from inside
+// the target pod's netns; configureNic calls it in its own ns.WithNetNSPath
+// block once all of the pod's interfaces are configured.
+func applyPodSysctls(sysctls map[string]string) error {
+	var rejected []string
+	for key := range sysctls {
+		if _, err := validatedSysctlPath(key); err != nil {
+			rejected = append(rejected, key)
+		}
+	}
+	if len(rejected) != 0 {
+		return &SysctlError{Keys: rejected}
+	}
+
+	for key, value := range sysctls {
+		path, err := validatedSysctlPath(key)
+		if err != nil {
+			// Unreachable: already validated above.
+			return err
+		}
+		if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
+			return fmt.Errorf("failed to set sysctl %s=%s: %v", key, value, err)
+		}
+	}
+	return nil
+}
+
+// validatedSysctlPath rejects anything that isn't a plain dotted net.*
+// sysctl key, then defends in depth by cleaning the resulting path and
+// re-checking it still falls under sysctlNetPrefix.
+func validatedSysctlPath(key string) (string, error) {
+	if !sysctlKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid sysctl key %q", key)
+	}
+	path := filepath.Clean(sysctlPath(key))
+	if !strings.HasPrefix(path, sysctlNetPrefix) {
+		return "", fmt.Errorf("sysctl key %q resolves outside %s", key, sysctlNetPrefix)
+	}
+	return path, nil
+}
+
+func sysctlPath(key string) string {
+	return "/proc/sys/" + strings.Replace(key, ".", "/", -1)
+}
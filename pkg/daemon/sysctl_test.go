@@ -0,0 +1,47 @@
+package daemon
+
+import "testing"
+
+func TestValidatedSysctlPathRejectsTraversal(t *testing.T) {
+	cases := []struct {
+		key     string
+		wantErr bool
+	}{
+		{"net.ipv4.conf.eth0.rp_filter", false},
+		{"net.ipv6.conf.all.disable_ipv6", false},
+		{"net/../../../etc/cron.d/x", true},
+		{"net.ipv4.conf.eth0/../../../etc/passwd", true},
+		{"vm.overcommit_memory", true},
+		{"kernel.panic", true},
+		{"..", true},
+		{"net", true},
+		{"", true},
+	}
+
+	for _, c := range cases {
+		path, err := validatedSysctlPath(c.key)
+		if c.wantErr && err == nil {
+			t.Errorf("validatedSysctlPath(%q) = %q, want error", c.key, path)
+		}
+		if !c.wantErr && err != nil {
+			t.Errorf("validatedSysctlPath(%q) returned unexpected error: %v", c.key, err)
+		}
+	}
+}
+
+func TestApplyPodSysctlsRejectsBeforeWriting(t *testing.T) {
+	err := applyPodSysctls(map[string]string{
+		"net.ipv4.conf.eth0.rp_filter": "0",
+		"net/../../../etc/cron.d/evil": "* * * * * root pwned",
+	})
+	if err == nil {
+		t.Fatal("expected applyPodSysctls to reject the traversal key, got nil error")
+	}
+	sysctlErr, ok := err.(*SysctlError)
+	if !ok {
+		t.Fatalf("expected *SysctlError, got %T: %v", err, err)
+	}
+	if len(sysctlErr.Keys) != 1 || sysctlErr.Keys[0] != "net/../../../etc/cron.d/evil" {
+		t.Fatalf("unexpected rejected keys: %v", sysctlErr.Keys)
+	}
+}
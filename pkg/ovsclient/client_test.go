@@ -0,0 +1,69 @@
+package ovsclient
+
+import (
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+// These need a real ovsdb-server reachable at defaultSocket, so they skip
+// rather than fail in environments (like CI sandboxes) without OVS running.
+
+func newTestClient(tb testing.TB) *Client {
+	tb.Helper()
+	c, err := New("")
+	if err != nil {
+		tb.Skipf("no ovsdb-server reachable, skipping: %v", err)
+	}
+	tb.Cleanup(c.Close)
+	return c
+}
+
+// BenchmarkAddDelPort_OVSDBClient measures pod-setup-path latency through
+// the persistent OVSDB connection, for comparison against
+// BenchmarkAddDelPort_OvsVsctl.
+func BenchmarkAddDelPort_OVSDBClient(b *testing.B) {
+	c := newTestClient(b)
+	for i := 0; i < b.N; i++ {
+		name := fmt.Sprintf("bench-ovsdb-%d", i)
+		if err := c.AddPort("br-int", name, "", map[string]string{"iface-id": name}); err != nil {
+			b.Fatalf("AddPort: %v", err)
+		}
+		if err := c.DelPort("br-int", name); err != nil {
+			b.Fatalf("DelPort: %v", err)
+		}
+	}
+}
+
+// BenchmarkAddDelPort_OvsVsctl is the baseline fork/exec path being
+// replaced, kept here so the two benchmarks are directly comparable with
+// `go test -bench`.
+func BenchmarkAddDelPort_OvsVsctl(b *testing.B) {
+	if _, err := exec.LookPath("ovs-vsctl"); err != nil {
+		b.Skipf("ovs-vsctl not found, skipping: %v", err)
+	}
+	for i := 0; i < b.N; i++ {
+		name := fmt.Sprintf("bench-vsctl-%d", i)
+		if out, err := exec.Command("ovs-vsctl", "add-port", "br-int", name).CombinedOutput(); err != nil {
+			b.Fatalf("add-port: %v: %s", err, out)
+		}
+		if out, err := exec.Command("ovs-vsctl", "--if-exists", "del-port", "br-int", name).CombinedOutput(); err != nil {
+			b.Fatalf("del-port: %v: %s", err, out)
+		}
+	}
+}
+
+// TestRoundTripAfterConnect checks a plain AddPort/DelPort round trip
+// against a real ovsdb-server. It does NOT exercise reconnection: that
+// would mean bouncing ovsdb-server mid-test, and these tests have no
+// infrastructure for restarting that process. New does not enable
+// libovsdb's reconnect option (see its doc comment).
+func TestRoundTripAfterConnect(t *testing.T) {
+	c := newTestClient(t)
+	if err := c.AddPort("br-int", "reconnect-probe", "", nil); err != nil {
+		t.Fatalf("AddPort: %v", err)
+	}
+	if err := c.DelPort("br-int", "reconnect-probe"); err != nil {
+		t.Fatalf("DelPort cleanup: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package ovsclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ovn-org/libovsdb/ovsdb"
+)
+
+// fakeTransactor records the operations it was asked to send and reports
+// them all as succeeding, so AddPort/DelPort's transaction shape can be
+// checked without a real ovsdb-server.
+type fakeTransactor struct {
+	gotOps []ovsdb.Operation
+}
+
+func (f *fakeTransactor) Transact(_ context.Context, ops ...ovsdb.Operation) ([]ovsdb.OperationResult, error) {
+	f.gotOps = ops
+	results := make([]ovsdb.OperationResult, len(ops))
+	return results, nil
+}
+
+func newFakeClient() (*Client, *fakeTransactor) {
+	fake := &fakeTransactor{}
+	return &Client{db: fake}, fake
+}
+
+// scriptedTransactor records every transaction it was asked to run, like
+// fakeTransactor, but also answers the Select op DelPort issues to look up
+// a Port row's real uuid - so DelPort's full select-then-mutate flow can be
+// exercised without a real ovsdb-server. An empty portUUID simulates the
+// Port row not existing (no rows returned).
+type scriptedTransactor struct {
+	portUUID string
+	calls    [][]ovsdb.Operation
+}
+
+func (f *scriptedTransactor) Transact(_ context.Context, ops ...ovsdb.Operation) ([]ovsdb.OperationResult, error) {
+	f.calls = append(f.calls, ops)
+	results := make([]ovsdb.OperationResult, len(ops))
+	for i, op := range ops {
+		if op.Op == ovsdb.OperationSelect && f.portUUID != "" {
+			results[i] = ovsdb.OperationResult{Rows: []ovsdb.Row{{"_uuid": ovsdb.UUID{GoUUID: f.portUUID}}}}
+		}
+	}
+	return results, nil
+}
+
+// TestDelPortMutatesBridgePortsWithRealUUID guards against both the
+// named-uuid bug (mutating Bridge.ports with a uuid never inserted in the
+// same transaction) and the dangling-reference bug (dropping the mutate
+// entirely, which ovsdb-server's referential integrity check rejects since
+// Bridge.ports is a strong reference): DelPort must look up the Port row's
+// real uuid first, then delete Port/Interface and mutate Bridge.ports with
+// that looked-up uuid.
+func TestDelPortMutatesBridgePortsWithRealUUID(t *testing.T) {
+	fake := &scriptedTransactor{portUUID: "deadbeef-0000-0000-0000-000000000000"}
+	c := &Client{db: fake}
+	if err := c.DelPort("br-int", "veth0"); err != nil {
+		t.Fatalf("DelPort: %v", err)
+	}
+
+	if len(fake.calls) != 2 {
+		t.Fatalf("expected a lookup select followed by a delete/mutate transaction, got %d calls: %+v", len(fake.calls), fake.calls)
+	}
+	lookup := fake.calls[0]
+	if len(lookup) != 1 || lookup[0].Op != ovsdb.OperationSelect || lookup[0].Table != "Port" {
+		t.Fatalf("expected first call to select the Port row, got: %+v", lookup)
+	}
+
+	var mutatedUUID ovsdb.UUID
+	var mutated, deletedPort, deletedIface bool
+	for _, op := range fake.calls[1] {
+		switch {
+		case op.Table == "Bridge":
+			for _, m := range op.Mutations {
+				if m.Column != "ports" {
+					continue
+				}
+				if m.Mutator != ovsdb.MutateOperationDelete {
+					t.Fatalf("expected Bridge.ports mutate to delete, got mutator %q", m.Mutator)
+				}
+				set, ok := m.Value.(ovsdb.OvsSet)
+				if !ok || len(set.GoSet) != 1 {
+					t.Fatalf("expected Bridge.ports mutate value to be a single-element OvsSet, got %+v", m.Value)
+				}
+				uuid, ok := set.GoSet[0].(ovsdb.UUID)
+				if !ok {
+					t.Fatalf("expected Bridge.ports mutate value to be a uuid, got %T", set.GoSet[0])
+				}
+				mutatedUUID = uuid
+				mutated = true
+			}
+		case op.Table == "Port" && op.Op == ovsdb.OperationDelete:
+			deletedPort = true
+		case op.Table == "Interface" && op.Op == ovsdb.OperationDelete:
+			deletedIface = true
+		}
+	}
+
+	if !mutated {
+		t.Fatal("expected a Bridge.ports mutate in the delete transaction")
+	}
+	if mutatedUUID.GoUUID != fake.portUUID {
+		t.Fatalf("Bridge.ports mutate references uuid %q, want the looked-up uuid %q", mutatedUUID.GoUUID, fake.portUUID)
+	}
+	if !deletedPort || !deletedIface {
+		t.Fatalf("expected Port and Interface rows to both be deleted, got ops: %+v", fake.calls[1])
+	}
+}
+
+// TestDelPortNoOpWhenPortMissing checks DelPort mirrors --if-exists: when
+// the lookup finds no Port row, it must not submit a delete/mutate
+// transaction at all (there would be no uuid to mutate Bridge.ports with).
+func TestDelPortNoOpWhenPortMissing(t *testing.T) {
+	fake := &scriptedTransactor{}
+	c := &Client{db: fake}
+	if err := c.DelPort("br-int", "veth0"); err != nil {
+		t.Fatalf("DelPort: %v", err)
+	}
+	if len(fake.calls) != 1 {
+		t.Fatalf("expected only the lookup select call, got %d calls: %+v", len(fake.calls), fake.calls)
+	}
+}
+
+// TestAddPortLinksPortToBridge checks AddPort's Bridge.ports mutate still
+// uses a uuid declared by an Insert earlier in the same transaction, which
+// is what makes it valid per RFC 7047 (unlike DelPort's old bug).
+func TestAddPortLinksPortToBridge(t *testing.T) {
+	c, fake := newFakeClient()
+	if err := c.AddPort("br-int", "veth0", "", map[string]string{"iface-id": "pod.ns"}); err != nil {
+		t.Fatalf("AddPort: %v", err)
+	}
+
+	declared := map[string]bool{}
+	for _, op := range fake.gotOps {
+		if op.Op == ovsdb.OperationInsert && op.UUIDName != "" {
+			declared[op.UUIDName] = true
+		}
+	}
+
+	found := false
+	for _, op := range fake.gotOps {
+		if op.Table != "Bridge" {
+			continue
+		}
+		for _, m := range op.Mutations {
+			if m.Column != "ports" {
+				continue
+			}
+			set, ok := m.Value.(ovsdb.OvsSet)
+			if !ok {
+				t.Fatalf("expected ports mutation value to be an OvsSet, got %T", m.Value)
+			}
+			for _, v := range set.GoSet {
+				uuid, ok := v.(ovsdb.UUID)
+				if !ok || !declared[uuid.GoUUID] {
+					t.Fatalf("Bridge.ports mutate references undeclared uuid %+v", v)
+				}
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected a Bridge.ports mutate referencing the inserted Port row")
+	}
+}